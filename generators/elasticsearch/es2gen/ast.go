@@ -0,0 +1,215 @@
+package es2gen
+
+import (
+	"strconv"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// filterNode is the version-agnostic internal representation emitted by
+// this package's make* builders. A Generator renders a filterNode into the
+// wire JSON for a specific Elasticsearch version, so teaching this package
+// about a new ES release is a matter of adding a render case here rather
+// than touching every builder.
+type filterNode interface {
+	render(v gentypes.ESVersion) interface{}
+}
+
+// andNode requires every one of its children to match, eg the legacy `and`
+// filter or a bool query's `filter`/`must` clause.
+type andNode struct {
+	of []filterNode
+}
+
+func (n *andNode) render(v gentypes.ESVersion) interface{} {
+	parts := make([]interface{}, len(n.of))
+	for i, f := range n.of {
+		parts[i] = f.render(v)
+	}
+	if v == gentypes.ESVersionLegacy {
+		return &and{parts}
+	}
+	return &boolFilter{boolQry{Filter: parts}}
+}
+
+// nestedNode scopes a child filter to documents under a nested path.
+type nestedNode struct {
+	path string
+	of   filterNode
+}
+
+func (n *nestedNode) render(v gentypes.ESVersion) interface{} {
+	inner := n.of.render(v)
+	if v == gentypes.ESVersionLegacy {
+		return &nested{&NestedFilter{Filter: inner, Path: n.path}}
+	}
+	return &boolNested{nestedQry{Path: n.path, Query: inner}}
+}
+
+// rangeNode renders unchanged across versions: the `range` query shape
+// hasn't changed since ES1.
+type rangeNode struct {
+	field string
+	qry   RangeQry
+}
+
+func (n *rangeNode) render(gentypes.ESVersion) interface{} {
+	return &RangeFilter{Range: map[string]RangeQry{n.field: n.qry}}
+}
+
+// termNode renders unchanged across versions: the `term` query shape hasn't
+// changed since ES1.
+type termNode struct {
+	field string
+	value interface{}
+}
+
+func (n *termNode) render(gentypes.ESVersion) interface{} {
+	return Term(n.field, n.value)
+}
+
+// wildcardNode renders unchanged across versions: the `wildcard` query shape
+// hasn't changed since ES1. When opts is non-nil the expanded object form
+// (value/case_insensitive/boost/rewrite) is emitted instead of the bare
+// field:value shorthand.
+type wildcardNode struct {
+	field string
+	value string
+	opts  *gentypes.FieldOptions
+}
+
+func (n *wildcardNode) render(gentypes.ESVersion) interface{} {
+	if n.opts == nil {
+		wc := Wildcard(n.field, n.value)
+		return &wc
+	}
+	return &WildcardFilter{map[string]wildcardQry{n.field: {
+		Value:           n.value,
+		CaseInsensitive: n.opts.CaseInsensitive,
+		Boost:           n.opts.Boost,
+		Rewrite:         n.opts.Rewrite,
+		Analyzer:        n.opts.Analyzer,
+	}}}
+}
+
+// termsSetNode renders unchanged across versions: the `terms_set` query
+// shape is ES6.1+ only and hasn't changed since.
+type termsSetNode struct {
+	field    string
+	values   []interface{}
+	minMatch MinMatch
+}
+
+func (n *termsSetNode) render(gentypes.ESVersion) interface{} {
+	qry := termsSetQry{Terms: n.values}
+	switch n.minMatch.kind {
+	case minMatchField:
+		qry.MinimumShouldMatchField = n.minMatch.field
+	case minMatchScript:
+		qry.MinimumShouldMatchScript = &scriptSrc{Source: n.minMatch.script}
+	default:
+		qry.MinimumShouldMatchScript = &scriptSrc{Source: strconv.Itoa(n.minMatch.count)}
+	}
+	return &TermsSetFilter{TermsSet: map[string]termsSetQry{n.field: qry}}
+}
+
+// termOptsNode renders an equality filter, using the expanded object form
+// when opts carries a case-insensitivity flag, a boost, or an analyzer, and the plain
+// `{"term": {field: value}}` shorthand otherwise. Unlike termNode (used
+// internally by other builders for fixed-shape terms like nested `.k`
+// markers), this is the builder-facing equality node that respects
+// FieldOptions.
+type termOptsNode struct {
+	field string
+	value interface{}
+	opts  *gentypes.FieldOptions
+}
+
+func (n *termOptsNode) render(gentypes.ESVersion) interface{} {
+	if n.opts == nil || (!n.opts.CaseInsensitive && n.opts.Boost == 0 && n.opts.Analyzer == "") {
+		return Term(n.field, n.value)
+	}
+	return &TermFilter{map[string]termQry{n.field: {
+		Value:           n.value,
+		CaseInsensitive: n.opts.CaseInsensitive,
+		Boost:           n.opts.Boost,
+		Analyzer:        n.opts.Analyzer,
+	}}}
+}
+
+// fuzzyNode renders unchanged across versions: the `fuzzy` query shape
+// hasn't changed since ES1.
+type fuzzyNode struct {
+	field string
+	value string
+	opts  *gentypes.FieldOptions
+}
+
+func (n *fuzzyNode) render(gentypes.ESVersion) interface{} {
+	fz := fuzzyQry{Value: n.value}
+	if n.opts != nil {
+		fz.Fuzziness = n.opts.Fuzziness
+		fz.PrefixLength = n.opts.PrefixLength
+		fz.MaxExpansions = n.opts.MaxExpansions
+	}
+	return &FuzzyFilter{map[string]fuzzyQry{n.field: fz}}
+}
+
+// existsNode renders unchanged across versions: the `exists` query shape
+// hasn't changed since ES2 introduced it.
+type existsNode struct {
+	field string
+}
+
+func (n *existsNode) render(gentypes.ESVersion) interface{} {
+	return &ExistsFilter{existsQry{Field: n.field}}
+}
+
+// mustNotNode negates its child, eg the legacy `bool.must_not` filter or a
+// bool query's `must_not` clause. Elasticsearch has had no native `!=`
+// operator since ES1, so this is how NotEqual and negated Exists are built.
+type mustNotNode struct {
+	of filterNode
+}
+
+func (n *mustNotNode) render(v gentypes.ESVersion) interface{} {
+	inner := n.of.render(v)
+	if v == gentypes.ESVersionLegacy {
+		return &mustNot{mustNotQry{MustNot: []interface{}{inner}}}
+	}
+	return &boolFilter{boolQry{MustNot: []interface{}{inner}}}
+}
+
+// multiMatchNode renders unchanged across versions: the `multi_match` query
+// shape hasn't changed since ES1.
+type multiMatchNode struct {
+	qry multiMatchQry
+}
+
+func (n *multiMatchNode) render(gentypes.ESVersion) interface{} {
+	return &MultiMatchFilter{n.qry}
+}
+
+// boolFilter is the ES5+ `{"bool": {...}}` structure used anywhere the
+// legacy generator would emit a top-level `and`/`filter` filter.
+type boolFilter struct {
+	Bool boolQry `json:"bool"`
+}
+
+type boolQry struct {
+	Must    []interface{} `json:"must,omitempty"`
+	MustNot []interface{} `json:"must_not,omitempty"`
+	Should  []interface{} `json:"should,omitempty"`
+	Filter  []interface{} `json:"filter,omitempty"`
+}
+
+// boolNested is the ES5+ `{"nested": {"path": ..., "query": {...}}}` clause,
+// replacing the legacy `{"nested": {"path": ..., "filter": {...}}}` form.
+type boolNested struct {
+	Nested nestedQry `json:"nested"`
+}
+
+type nestedQry struct {
+	Path  string      `json:"path"`
+	Query interface{} `json:"query"`
+}
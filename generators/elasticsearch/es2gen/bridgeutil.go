@@ -58,6 +58,31 @@ func scalar(node expr.Node) (interface{}, bool) {
 	return "", false
 }
 
+// isNullLiteral reports whether node is the SQL `NULL` keyword, which the
+// parser carries as a bare identity node (the same reason scalar() above
+// doesn't handle it: it's not a typed literal). buildBinary uses this to
+// tell `foo != NULL`/`foo = NULL` (ie `IS [NOT] NULL`) apart from an
+// ordinary comparison against a literal.
+func isNullLiteral(node expr.Node) bool {
+	ident, ok := node.(*expr.IdentityNode)
+	return ok && strings.EqualFold(ident.Text, "null")
+}
+
+// fuzzySuffix splits a LIKE pattern's trailing `~N` fuzziness suffix (eg
+// `foo~2`, the SQL-level spelling of a fuzzy query's edit distance) from its
+// value. ok is false when pattern has no such suffix, so the LIKE dispatch
+// case falls back to a plain wildcard.
+func fuzzySuffix(pattern string) (value, fuzziness string, ok bool) {
+	idx := strings.LastIndexByte(pattern, '~')
+	if idx < 0 || idx == len(pattern)-1 {
+		return "", "", false
+	}
+	if _, err := strconv.Atoi(pattern[idx+1:]); err != nil {
+		return "", "", false
+	}
+	return pattern[:idx], pattern[idx+1:], true
+}
+
 // makeRange returns a range filter for Elasticsearch given the 3 nodes that
 // make up a comparison.
 func makeRange(lhs *gentypes.FieldType, op lex.TokenType, rhs expr.Node) (interface{}, error) {
@@ -127,27 +152,7 @@ func makeRange(lhs *gentypes.FieldType, op lex.TokenType, rhs expr.Node) (interf
 				}}}}
 	*/
 
-	fieldName := lhs.Field
-	if lhs.Nested() {
-		fieldName, rhsval = lhs.PrefixAndValue(rhsval)
-	}
-	r := &RangeFilter{}
-	switch op {
-	case lex.TokenGE:
-		r.Range = map[string]RangeQry{fieldName: RangeQry{GTE: rhsval}}
-	case lex.TokenLE:
-		r.Range = map[string]RangeQry{fieldName: RangeQry{LTE: rhsval}}
-	case lex.TokenGT:
-		r.Range = map[string]RangeQry{fieldName: RangeQry{GT: rhsval}}
-	case lex.TokenLT:
-		r.Range = map[string]RangeQry{fieldName: RangeQry{LT: rhsval}}
-	default:
-		return nil, fmt.Errorf("qlindex: unsupported range operator %s", op)
-	}
-	if lhs.Nested() {
-		return Nested(lhs, r), nil
-	}
-	return r, nil
+	return legacyGenerator().Range(lhs, op, rhsval)
 }
 
 // makeBetween returns a range filter for Elasticsearch given the 3 nodes that
@@ -191,23 +196,15 @@ func makeBetween(lhs *gentypes.FieldType, lower, upper interface{}) (interface{}
 		]
 	*/
 
-	lr := &RangeFilter{Range: map[string]RangeQry{lhs.Field: RangeQry{GT: lower}}}
-	ur := &RangeFilter{Range: map[string]RangeQry{lhs.Field: RangeQry{LT: upper}}}
-	fl := []interface{}{lr, ur}
-
-	if lhs.Nested() {
-		fl = append(fl, Term("k", lhs.Field))
-		return &nested{&NestedFilter{
-			Filter: &and{fl},
-			Path:   lhs.Path,
-		}}, nil
-	}
-	return &and{fl}, nil
+	return legacyGenerator().Between(lhs, lower, upper)
 }
 
 // makeWildcard returns a wildcard/like query
 //  {"query": {"wildcard": {field: value}}}
-func makeWildcard(lhs *gentypes.FieldType, value string) (interface{}, error) {
+// When opts is given, the expanded `{"wildcard": {field: {"value": v, ...}}}`
+// form is emitted instead, carrying whichever of analyzer/case_insensitive/
+// boost/rewrite it sets.
+func makeWildcard(lhs *gentypes.FieldType, value string, opts ...gentypes.FieldOptions) (interface{}, error) {
 	/*
 		"nested": {
 			"filter": {
@@ -224,20 +221,7 @@ func makeWildcard(lhs *gentypes.FieldType, value string) (interface{}, error) {
 
 		{"query": {"wildcard": {field: value}}}
 	*/
-	fieldName := lhs.Field
-
-	if lhs.Nested() {
-		fieldName = lhs.PathAndPrefix(value)
-	}
-	wc := Wildcard(fieldName, value)
-	if lhs.Nested() {
-		fl := []interface{}{wc, Term(fmt.Sprintf("%s.k", lhs.Path), lhs.Field)}
-		return &nested{&NestedFilter{
-			Filter: &and{fl},
-			Path:   lhs.Path,
-		}}, nil
-	}
-	return &wc, nil
+	return legacyGenerator().Wildcard(lhs, value, opts...)
 }
 
 // esName return the Elasticsearch field name for an identity node or an error.
@@ -281,6 +265,21 @@ func esName(m gentypes.FieldMapper, n expr.Node) (*gentypes.FieldType, error) {
 	return nil, gentypes.MissingField(ident.OriginalText())
 }
 
+// comparisonLHS resolves the left-hand side of a binary comparison to its
+// mapped field. The bool result follows buildBinary/buildFunc's own
+// recognized-operator convention: false means n isn't an identity at all
+// (arithmetic across fields, string concatenation, a CASE expression, ...),
+// so the caller should fall through to the script path instead of
+// surfacing esName's "expected an identity" as a dispatch error; true means
+// err (nil or not) is authoritative, including a genuine mapper miss.
+func comparisonLHS(m gentypes.FieldMapper, n expr.Node) (*gentypes.FieldType, error, bool) {
+	if _, ok := n.(*expr.IdentityNode); !ok {
+		return nil, nil, false
+	}
+	ft, err := esName(m, n)
+	return ft, err, true
+}
+
 // makeTimeWindowQuery maps the provided threshold and window arguments to the indexed time buckets
 func makeTimeWindowQuery(lhs *gentypes.FieldType, threshold, window, ts int64) (interface{}, error) {
 	/*
@@ -309,15 +308,5 @@ func makeTimeWindowQuery(lhs *gentypes.FieldType, threshold, window, ts int64) (
 		}
 	*/
 
-	fl := []interface{}{
-		Term(lhs.Field+".threshold", strconv.FormatInt(threshold, 10)),
-		Term(lhs.Field+".window", strconv.FormatInt(window, 10)),
-		&RangeFilter{Range: map[string]RangeQry{lhs.Field + ".enter": RangeQry{LTE: ts}}},
-		&RangeFilter{Range: map[string]RangeQry{lhs.Field + ".exit": RangeQry{GTE: ts}}},
-	}
-
-	return &nested{&NestedFilter{
-		Filter: &and{fl},
-		Path:   lhs.Field,
-	}}, nil
+	return legacyGenerator().TimeWindowQuery(lhs, threshold, window, ts)
 }
@@ -0,0 +1,246 @@
+package es2gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// Build is the parent switch that turns a SQL-level boolean expression node
+// into an Elasticsearch query body, dispatching to this package's make*
+// builders. It is the single place a new SQL construct gets wired to its
+// generator: as builders are added to this package, they get a case here
+// rather than living as an uncalled, untested free function.
+//
+// Build tries the native builders first and only falls through to a
+// Painless script (makeScriptFilter) once a sub-expression doesn't match
+// any of the cases below, so straightforward queries stay native, fast,
+// and cacheable.
+func (g *Generator) Build(m gentypes.FieldMapper, node expr.Node) (interface{}, error) {
+	switch n := node.(type) {
+	case *expr.BinaryNode:
+		if out, err, ok := g.buildBinary(m, n); ok {
+			return out, err
+		}
+	case *expr.FuncNode:
+		if out, err, ok := g.buildFunc(m, n); ok {
+			return out, err
+		}
+	}
+	return makeScriptFilter(m, node)
+}
+
+// buildBinary handles the comparison operators this package has native
+// builders for. The bool return reports whether n is both a recognized
+// operator and a plain `field <op> literal` shape: false tells Build to
+// fall through to the script path rather than surface a spurious error,
+// covering both an operator this function doesn't handle (eg arithmetic,
+// which belongs to the painless package) and a recognized operator whose
+// LHS isn't a field reference at all (arithmetic across fields, string
+// concatenation, a CASE expression, ...) via comparisonLHS.
+func (g *Generator) buildBinary(m gentypes.FieldMapper, n *expr.BinaryNode) (interface{}, error, bool) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("qlindex: binary node with %d args", len(n.Args)), true
+	}
+
+	switch n.Operator.T {
+	case lex.TokenGE, lex.TokenLE, lex.TokenGT, lex.TokenLT:
+		lhs, err, ok := comparisonLHS(m, n.Args[0])
+		if !ok {
+			return nil, nil, false
+		}
+		if err != nil {
+			return nil, err, true
+		}
+		rhsval, ok := scalar(n.Args[1])
+		if !ok {
+			return nil, nil, false
+		}
+		// ES handles ints as strings fine, but a numeric field compared
+		// against a float-shaped string should still compare numerically.
+		if lhs.Numeric() {
+			if rhsstr, ok := rhsval.(string); ok {
+				if rhsf, err := strconv.ParseFloat(rhsstr, 64); err == nil {
+					rhsval = rhsf
+				}
+			}
+		}
+		out, err := g.Range(lhs, n.Operator.T, rhsval)
+		return out, err, true
+
+	// IS NULL is spelled `foo = NULL` at the AST level (the parser folds
+	// the keyword form down to the same BinaryNode shape as a literal
+	// comparison), so it shares a case with ordinary equality.
+	case lex.TokenEqualEqual:
+		lhs, err, ok := comparisonLHS(m, n.Args[0])
+		if !ok {
+			return nil, nil, false
+		}
+		if err != nil {
+			return nil, err, true
+		}
+		if isNullLiteral(n.Args[1]) {
+			out, err := g.Exists(lhs, true)
+			return out, err, true
+		}
+		rhsval, ok := scalar(n.Args[1])
+		if !ok {
+			return nil, nil, false
+		}
+		out, err := g.Term(lhs, rhsval)
+		return out, err, true
+
+	case lex.TokenNE:
+		lhs, err, ok := comparisonLHS(m, n.Args[0])
+		if !ok {
+			return nil, nil, false
+		}
+		if err != nil {
+			return nil, err, true
+		}
+		// `foo != NULL` is the SQL spelling of `foo IS NOT NULL`.
+		if isNullLiteral(n.Args[1]) {
+			out, err := g.Exists(lhs, false)
+			return out, err, true
+		}
+		if _, ok := scalar(n.Args[1]); !ok {
+			return nil, nil, false
+		}
+		out, err := g.NotEqual(lhs, n.Args[1])
+		return out, err, true
+
+	case lex.TokenLike:
+		lhs, err, ok := comparisonLHS(m, n.Args[0])
+		if !ok {
+			return nil, nil, false
+		}
+		if err != nil {
+			return nil, err, true
+		}
+		rhsval, ok := scalar(n.Args[1])
+		if !ok {
+			return nil, nil, false
+		}
+		pattern, ok := rhsval.(string)
+		if !ok {
+			return nil, fmt.Errorf("qlindex: LIKE pattern must be a string, got %T", rhsval), true
+		}
+		// A trailing `~N` (eg `field LIKE 'foo~2'`) is the SQL-level spelling
+		// of a fuzzy query's edit distance rather than a literal wildcard.
+		if value, fuzziness, ok := fuzzySuffix(pattern); ok {
+			out, err := g.Fuzzy(lhs, value, gentypes.FieldOptions{Fuzziness: fuzziness})
+			return out, err, true
+		}
+		out, err := g.Wildcard(lhs, pattern)
+		return out, err, true
+	}
+	return nil, nil, false
+}
+
+// buildFunc handles SQL builtins backed by a make* builder in this package.
+// The bool return mirrors buildBinary: false means n.Name wasn't one of
+// ours, so Build should fall through to the script path instead of erroring.
+func (g *Generator) buildFunc(m gentypes.FieldMapper, n *expr.FuncNode) (interface{}, error, bool) {
+	switch strings.ToLower(n.Name) {
+	case "match":
+		out, err := g.buildMatch(m, n.Args)
+		return out, err, true
+	case "terms_set":
+		out, err := g.buildTermsSet(m, n.Args)
+		return out, err, true
+	}
+	return nil, nil, false
+}
+
+// buildMatch backs the `MATCH(field1, field2, ... , 'query text')` builtin:
+// every argument but the last names a field to search, the last is the
+// query text, eg the SQL-level spelling of `MATCH(...) AGAINST (...)`.
+func (g *Generator) buildMatch(m gentypes.FieldMapper, args []expr.Node) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("qlindex: MATCH requires at least one field and a query, got %d args", len(args))
+	}
+	fields, err := multiMatchFields(m, args[:len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	query, ok := scalar(args[len(args)-1])
+	if !ok {
+		return nil, fmt.Errorf("qlindex: MATCH query argument must be a literal, got %T", args[len(args)-1])
+	}
+	queryStr, ok := query.(string)
+	if !ok {
+		return nil, fmt.Errorf("qlindex: MATCH query argument must be a string, got %T", query)
+	}
+	// MATCH()/AGAINST() has no SQL-level syntax for tuning best_fields
+	// relevance, so the builtin always renders these defaults; a caller
+	// needing different knobs should build a MultiMatchOptions directly.
+	return g.MultiMatch(fields, queryStr, MultiMatchOptions{
+		Type:       MultiMatchBestFields,
+		TieBreaker: 0.3,
+		Fuzziness:  "AUTO",
+		Operator:   "and",
+	})
+}
+
+// buildTermsSet backs the `TERMS_SET(field, (...values), minMatch)` builtin:
+// minMatch may be an integer literal (NewMinMatchCount), an identifier
+// naming a field on the document holding the count (NewMinMatchField), or a
+// string holding a Painless script that computes it (NewMinMatchScript).
+func (g *Generator) buildTermsSet(m gentypes.FieldMapper, args []expr.Node) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("qlindex: TERMS_SET requires (field, values, min_match), got %d args", len(args))
+	}
+	lhs, err := esName(m, args[0])
+	if err != nil {
+		return nil, err
+	}
+	values, err := termsSetValues(args[1])
+	if err != nil {
+		return nil, err
+	}
+	minMatch, err := termsSetMinMatch(args[2])
+	if err != nil {
+		return nil, err
+	}
+	return g.TermsSet(lhs, values, minMatch)
+}
+
+// termsSetValues resolves the literal tuple of values passed as TERMS_SET's
+// second argument, eg `('a', 'b', 'c')`.
+func termsSetValues(node expr.Node) ([]interface{}, error) {
+	tuple, ok := node.(*expr.ArrayNode)
+	if !ok {
+		return nil, fmt.Errorf("qlindex: TERMS_SET requires a literal tuple of values, got %T", node)
+	}
+	values := make([]interface{}, 0, len(tuple.Args))
+	for _, arg := range tuple.Args {
+		val, ok := scalar(arg)
+		if !ok {
+			return nil, fmt.Errorf("qlindex: TERMS_SET values must be literals, got %T", arg)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// termsSetMinMatch resolves TERMS_SET's third argument to a MinMatch.
+func termsSetMinMatch(node expr.Node) (MinMatch, error) {
+	switch n := node.(type) {
+	case *expr.NumberNode:
+		if !n.IsInt {
+			return MinMatch{}, fmt.Errorf("qlindex: TERMS_SET minimum match count must be an integer, got %v", n.Float64)
+		}
+		return NewMinMatchCount(int(n.Int64)), nil
+	case *expr.IdentityNode:
+		return NewMinMatchField(n.Text), nil
+	case *expr.StringNode:
+		return NewMinMatchScript(n.Text), nil
+	default:
+		return MinMatch{}, fmt.Errorf("qlindex: unsupported TERMS_SET minimum match argument type %T", node)
+	}
+}
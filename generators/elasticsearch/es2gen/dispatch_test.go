@@ -0,0 +1,246 @@
+package es2gen
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// dispatchMapper is a minimal gentypes.FieldMapper for exercising
+// Generator.Build without a real qlbridge schema.
+type dispatchMapper struct {
+	fields map[string]*gentypes.FieldType
+}
+
+func (m dispatchMapper) Map(field string) (*gentypes.FieldType, bool) {
+	ft, ok := m.fields[field]
+	return ft, ok
+}
+
+func newDispatchMapper(fields ...*gentypes.FieldType) dispatchMapper {
+	m := dispatchMapper{fields: map[string]*gentypes.FieldType{}}
+	for _, ft := range fields {
+		m.fields[ft.Field] = ft
+	}
+	return m
+}
+
+func TestBuildMatchDispatchesToMultiMatch(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "title"}, &gentypes.FieldType{Field: "body"})
+	g := legacyGenerator()
+
+	node := &expr.FuncNode{Name: "match", Args: []expr.Node{
+		&expr.IdentityNode{Text: "title"},
+		&expr.IdentityNode{Text: "body"},
+		&expr.StringNode{Text: "foo bar"},
+	}}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Unlike TestMakeMultiMatchNonNested (which exercises makeMultiMatch
+	// directly with zero-value options), dispatching MATCH() through Build
+	// applies buildMatch's best_fields/tie_breaker/fuzziness/operator
+	// defaults, so this has its own golden file.
+	golden(t, "dispatch.match.json", got)
+}
+
+// TestBuildMatchNestedRendersThroughGeneratorVersion guards the chunk0-3
+// fix: a MATCH() over nested fields must render the ES5+ nested/query shape
+// when the Generator targets ES5+, not the legacy nested/filter shape
+// makeMultiMatch always emits.
+func TestBuildMatchNestedRendersThroughGeneratorVersion(t *testing.T) {
+	m := newDispatchMapper(
+		&gentypes.FieldType{Field: "title", Path: "map_events"},
+		&gentypes.FieldType{Field: "body", Path: "map_events"},
+	)
+	g := NewGenerator(m, gentypes.ESVersion7)
+
+	node := &expr.FuncNode{Name: "match", Args: []expr.Node{
+		&expr.IdentityNode{Text: "title"},
+		&expr.IdentityNode{Text: "body"},
+		&expr.StringNode{Text: "foo"},
+	}}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "dispatch.match.nested.es7.json", got)
+}
+
+func TestBuildRangeDispatchesToRange(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "age"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenGE},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "age"}, &expr.NumberNode{IsInt: true, Int64: 21}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "dispatch.range.json", got)
+}
+
+func TestBuildNotEqualDispatchesToNotEqual(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "status"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenNE},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "status"}, &expr.StringNode{Text: "closed"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "notequal.nonnested.json", got)
+}
+
+func TestBuildIsNullDispatchesToExists(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "status"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenEqualEqual},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "status"}, &expr.IdentityNode{Text: "NULL"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "exists.nonnested.negated.json", got)
+}
+
+func TestBuildIsNotNullDispatchesToExists(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "status"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenNE},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "status"}, &expr.IdentityNode{Text: "NULL"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "exists.nonnested.json", got)
+}
+
+func TestBuildTermsSetDispatchesToTermsSet(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "tags"})
+	g := legacyGenerator()
+
+	node := &expr.FuncNode{Name: "terms_set", Args: []expr.Node{
+		&expr.IdentityNode{Text: "tags"},
+		&expr.ArrayNode{Args: []expr.Node{
+			&expr.StringNode{Text: "a"},
+			&expr.StringNode{Text: "b"},
+			&expr.StringNode{Text: "c"},
+		}},
+		&expr.NumberNode{IsInt: true, Int64: 2},
+	}}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "termsset.count.json", got)
+}
+
+func TestBuildEqualDispatchesToTerm(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "status"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenEqualEqual},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "status"}, &expr.StringNode{Text: "open"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "term.nonnested.json", got)
+}
+
+func TestBuildLikeDispatchesToWildcard(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "title"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenLike},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "title"}, &expr.StringNode{Text: "hel*"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "wildcard.nonnested.json", got)
+}
+
+// TestBuildComparisonFallsThroughToScriptForNonFieldLHS guards the chunk0-5
+// scenarios (arithmetic across fields, concatenation, CASE) compared to a
+// literal: none of them have a field reference as the LHS, so they must
+// fall through to the script path rather than surface esName's "expected an
+// identity" as a dispatch error.
+func TestBuildComparisonFallsThroughToScriptForNonFieldLHS(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "a"}, &gentypes.FieldType{Field: "b"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenGT},
+		Args: []expr.Node{
+			&expr.BinaryNode{
+				Operator: lex.Token{T: lex.TokenPlus},
+				Args:     []expr.Node{&expr.IdentityNode{Text: "a"}, &expr.IdentityNode{Text: "b"}},
+			},
+			&expr.NumberNode{IsInt: true, Int64: 100},
+		},
+	}
+
+	if _, err := g.Build(m, node); err != nil {
+		t.Fatalf("unexpected error falling through to script: %v", err)
+	}
+}
+
+func TestBuildLikeWithFuzzySuffixDispatchesToFuzzy(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "title"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenLike},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "title"}, &expr.StringNode{Text: "kitten~2"}},
+	}
+
+	got, err := g.Build(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "fuzzy.nonnested.json", got)
+}
+
+func TestBuildUnhandledOperatorFallsThroughToScript(t *testing.T) {
+	m := newDispatchMapper(&gentypes.FieldType{Field: "amount"})
+	g := legacyGenerator()
+
+	node := &expr.BinaryNode{
+		Operator: lex.Token{T: lex.TokenPlus},
+		Args:     []expr.Node{&expr.IdentityNode{Text: "amount"}, &expr.NumberNode{IsInt: true, Int64: 1}},
+	}
+
+	if _, err := g.Build(m, node); err != nil {
+		t.Fatalf("unexpected error falling through to script: %v", err)
+	}
+}
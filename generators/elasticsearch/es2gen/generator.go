@@ -0,0 +1,286 @@
+package es2gen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// Generator renders the version-agnostic filter AST built by this package's
+// make* builders into the Elasticsearch wire format for a specific ES
+// release. The legacy package-level make* functions always render the
+// ES1.x/2.x filtered-query form for backwards compatibility; a Generator
+// lets callers opt into the ES5+ bool query DSL instead.
+type Generator struct {
+	mapper  gentypes.FieldMapper
+	version gentypes.ESVersion
+}
+
+// NewGenerator returns a Generator that maps fields via mapper and renders
+// filters for the given Elasticsearch version.
+func NewGenerator(mapper gentypes.FieldMapper, version gentypes.ESVersion) *Generator {
+	return &Generator{mapper: mapper, version: version}
+}
+
+// legacyGenerator returns a mapper-less Generator targeting the legacy ES
+// filtered-query DSL. The package-level make* functions are thin wrappers
+// around Generator methods rendered with it, so there's exactly one
+// implementation of the nested-path handling for each filter kind.
+func legacyGenerator() *Generator {
+	return &Generator{version: gentypes.ESVersionLegacy}
+}
+
+// Range returns a range filter rendered for g's target ES version.
+func (g *Generator) Range(lhs *gentypes.FieldType, op lex.TokenType, rhsval interface{}) (interface{}, error) {
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName, rhsval = lhs.PrefixAndValue(rhsval)
+	}
+
+	qry := RangeQry{}
+	switch op {
+	case lex.TokenGE:
+		qry.GTE = rhsval
+	case lex.TokenLE:
+		qry.LTE = rhsval
+	case lex.TokenGT:
+		qry.GT = rhsval
+	case lex.TokenLT:
+		qry.LT = rhsval
+	default:
+		return nil, fmt.Errorf("qlindex: unsupported range operator %s", op)
+	}
+
+	var node filterNode = &rangeNode{field: fieldName, qry: qry}
+	if lhs.Nested() {
+		node = &nestedNode{path: lhs.Path, of: node}
+	}
+	return node.render(g.version), nil
+}
+
+// Between returns a range filter testing lower < lhs < upper, rendered for
+// g's target ES version.
+func (g *Generator) Between(lhs *gentypes.FieldType, lower, upper interface{}) (interface{}, error) {
+	fl := []filterNode{
+		&rangeNode{field: lhs.Field, qry: RangeQry{GT: lower}},
+		&rangeNode{field: lhs.Field, qry: RangeQry{LT: upper}},
+	}
+
+	if lhs.Nested() {
+		fl = append(fl, &termNode{field: "k", value: lhs.Field})
+		return (&nestedNode{path: lhs.Path, of: &andNode{fl}}).render(g.version), nil
+	}
+	return (&andNode{fl}).render(g.version), nil
+}
+
+// Wildcard returns a wildcard/like filter, rendered for g's target ES
+// version. opts, if given, configures case sensitivity, boost, and rewrite
+// mode on the generated query.
+func (g *Generator) Wildcard(lhs *gentypes.FieldType, value string, opts ...gentypes.FieldOptions) (interface{}, error) {
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName = lhs.PathAndPrefix(value)
+	}
+
+	var node filterNode = &wildcardNode{field: fieldName, value: value, opts: resolveOptions(g.mapper, lhs.Field, opts...)}
+	if lhs.Nested() {
+		fl := []filterNode{node, &termNode{field: lhs.Path + ".k", value: lhs.Field}}
+		node = &nestedNode{path: lhs.Path, of: &andNode{fl}}
+	}
+	return node.render(g.version), nil
+}
+
+// MultiMatch returns a multi_match query across fields, rendered for g's
+// target ES version.
+//
+// When every field shares the same nested path the multi_match is wrapped
+// in a nested filter for that path. Fields that span more than one nested
+// path, or mix a nested field with a non-nested one, cannot be searched
+// together in a single multi_match and return an error.
+func (g *Generator) MultiMatch(fields []*gentypes.FieldType, value string, opts MultiMatchOptions) (interface{}, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("qlindex: multi_match requires at least one field")
+	}
+
+	mmType := opts.Type
+	if mmType == "" {
+		mmType = MultiMatchBestFields
+	}
+
+	// Find the (at most one) nested path these fields share, regardless of
+	// which position in fields it first appears at, then validate every
+	// field against it in a second pass so order can't hide a mismatch.
+	var nestedField *gentypes.FieldType
+	hasNonNested := false
+	for _, f := range fields {
+		if f.Nested() {
+			if nestedField == nil {
+				nestedField = f
+			} else if f.Path != nestedField.Path {
+				return nil, fmt.Errorf("qlindex: multi_match cannot span nested paths %q and %q", nestedField.Path, f.Path)
+			}
+		} else {
+			hasNonNested = true
+		}
+	}
+	if nestedField != nil && hasNonNested {
+		return nil, fmt.Errorf("qlindex: multi_match cannot combine nested field %q with non-nested field", nestedField.Field)
+	}
+
+	fieldNames := make([]string, 0, len(fields))
+	for _, f := range fields {
+		name := f.Field
+		if boost, ok := opts.FieldBoosts[f.Field]; ok {
+			name = fmt.Sprintf("%s^%s", name, strconv.FormatFloat(boost, 'g', -1, 64))
+		}
+		fieldNames = append(fieldNames, name)
+	}
+
+	var node filterNode = &multiMatchNode{multiMatchQry{
+		Query:              value,
+		Fields:             fieldNames,
+		Type:               string(mmType),
+		TieBreaker:         opts.TieBreaker,
+		Boost:              opts.Boost,
+		Analyzer:           opts.Analyzer,
+		Fuzziness:          opts.Fuzziness,
+		MaxExpansions:      opts.MaxExpansions,
+		PrefixLength:       opts.PrefixLength,
+		MinimumShouldMatch: opts.MinimumShouldMatch,
+		Operator:           opts.Operator,
+	}}
+	if nestedField != nil {
+		node = &nestedNode{path: nestedField.Path, of: node}
+	}
+	return node.render(g.version), nil
+}
+
+// TermsSet returns a terms_set filter testing that lhs matches at least
+// minMatch of values, rendered for g's target ES version. For nested
+// fields the query is wrapped in the standard nested/`k` term pattern used
+// by the other builders in this package.
+func (g *Generator) TermsSet(lhs *gentypes.FieldType, values []interface{}, minMatch MinMatch) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("qlindex: terms_set requires at least one value")
+	}
+
+	fieldName := lhs.Field
+	vals := values
+	if lhs.Nested() {
+		vals = make([]interface{}, len(values))
+		for i, val := range values {
+			fieldName, vals[i] = lhs.PrefixAndValue(val)
+		}
+	}
+
+	var node filterNode = &termsSetNode{field: fieldName, values: vals, minMatch: minMatch}
+	if lhs.Nested() {
+		fl := []filterNode{node, &termNode{field: lhs.Path + ".k", value: lhs.Field}}
+		node = &nestedNode{path: lhs.Path, of: &andNode{fl}}
+	}
+	return node.render(g.version), nil
+}
+
+// Term returns an equality filter for lhs, rendered for g's target ES
+// version. opts, if given (or resolvable from g's mapper via
+// gentypes.FieldOptioner), configures case sensitivity and boost.
+func (g *Generator) Term(lhs *gentypes.FieldType, value interface{}, opts ...gentypes.FieldOptions) (interface{}, error) {
+	fo := resolveOptions(g.mapper, lhs.Field, opts...)
+
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName, value = lhs.PrefixAndValue(value)
+	}
+
+	var node filterNode = &termOptsNode{field: fieldName, value: value, opts: fo}
+	if lhs.Nested() {
+		fl := []filterNode{node, &termNode{field: lhs.Path + ".k", value: lhs.Field}}
+		node = &nestedNode{path: lhs.Path, of: &andNode{fl}}
+	}
+	return node.render(g.version), nil
+}
+
+// Fuzzy returns a fuzzy query for lhs, rendered for g's target ES version.
+// opts (or a gentypes.FieldOptioner on g's mapper) must supply a Fuzziness.
+func (g *Generator) Fuzzy(lhs *gentypes.FieldType, value string, opts ...gentypes.FieldOptions) (interface{}, error) {
+	fo := resolveOptions(g.mapper, lhs.Field, opts...)
+	if fo == nil || fo.Fuzziness == "" {
+		return nil, fmt.Errorf("qlindex: fuzzy query on %q requires a fuzziness option", lhs.Field)
+	}
+
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName = lhs.PathAndPrefix(value)
+	}
+
+	var node filterNode = &fuzzyNode{field: fieldName, value: value, opts: fo}
+	if lhs.Nested() {
+		fl := []filterNode{node, &termNode{field: lhs.Path + ".k", value: lhs.Field}}
+		node = &nestedNode{path: lhs.Path, of: &andNode{fl}}
+	}
+	return node.render(g.version), nil
+}
+
+// NotEqual returns a filter for `lhs != rhs`, expressed as a term query
+// wrapped in must_not since Elasticsearch has no native != operator,
+// rendered for g's target ES version.
+func (g *Generator) NotEqual(lhs *gentypes.FieldType, rhs expr.Node) (interface{}, error) {
+	rhsval, ok := scalar(rhs)
+	if !ok {
+		return nil, fmt.Errorf("qlindex: unsupported type for comparison: %T", rhs)
+	}
+
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName, rhsval = lhs.PrefixAndValue(rhsval)
+		fl := []filterNode{
+			&termNode{field: lhs.Path + ".k", value: lhs.Field},
+			&mustNotNode{&termNode{field: fieldName, value: rhsval}},
+		}
+		return (&nestedNode{path: lhs.Path, of: &andNode{fl}}).render(g.version), nil
+	}
+
+	var node filterNode = &mustNotNode{&termNode{field: fieldName, value: rhsval}}
+	return node.render(g.version), nil
+}
+
+// Exists returns a filter testing for the presence of lhs. When negated is
+// true the filter is wrapped in must_not so it instead tests for absence,
+// ie `IS NULL`, rendered for g's target ES version.
+//
+// For nested fields presence of the value means the mapped key is present
+// in one of the nested documents under lhs.Path, so the exists check is
+// just the `k` sub-key term match, mirroring the pattern used by Wildcard/
+// Term/Fuzzy for the nested case — no separate exists query is needed since
+// the `k` join itself is the existence check.
+func (g *Generator) Exists(lhs *gentypes.FieldType, negated bool) (interface{}, error) {
+	if lhs.Nested() {
+		var inner filterNode = &termNode{field: lhs.Path + ".k", value: lhs.Field}
+		if negated {
+			inner = &mustNotNode{inner}
+		}
+		return (&nestedNode{path: lhs.Path, of: inner}).render(g.version), nil
+	}
+
+	var node filterNode = &existsNode{field: lhs.Field}
+	if negated {
+		node = &mustNotNode{node}
+	}
+	return node.render(g.version), nil
+}
+
+// TimeWindowQuery maps the provided threshold and window arguments to the
+// indexed time buckets, rendered for g's target ES version.
+func (g *Generator) TimeWindowQuery(lhs *gentypes.FieldType, threshold, window, ts int64) (interface{}, error) {
+	fl := []filterNode{
+		&termNode{field: lhs.Field + ".threshold", value: strconv.FormatInt(threshold, 10)},
+		&termNode{field: lhs.Field + ".window", value: strconv.FormatInt(window, 10)},
+		&rangeNode{field: lhs.Field + ".enter", qry: RangeQry{LTE: ts}},
+		&rangeNode{field: lhs.Field + ".exit", qry: RangeQry{GTE: ts}},
+	}
+	return (&nestedNode{path: lhs.Field, of: &andNode{fl}}).render(g.version), nil
+}
@@ -0,0 +1,63 @@
+package es2gen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// golden compares the JSON marshaling of got against the contents of
+// testdata/name, failing with a diff-friendly message if they differ.
+func golden(t *testing.T, name string, got interface{}) {
+	t.Helper()
+
+	want, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	gotBytes, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshaling result: %v", err)
+	}
+
+	gotCompact, wantCompact := compactJSON(t, gotBytes), compactJSON(t, want)
+	if gotCompact != wantCompact {
+		t.Errorf("got:\n%s\nwant:\n%s", gotCompact, wantCompact)
+	}
+}
+
+func compactJSON(t *testing.T, b []byte) string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("unmarshaling %s: %v", b, err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("remarshaling: %v", err)
+	}
+	return string(out)
+}
+
+// nestedRangeFixture builds a nested filter of the shape makeWildcard uses
+// for a nested field match (a value clause plus the "k" presence term), here
+// with a range on "f" as the value clause, so it can be rendered through
+// both dialects and diffed against golden files.
+func nestedRangeFixture() filterNode {
+	fl := []filterNode{
+		&rangeNode{field: "map_events.f", qry: RangeQry{GTE: 7}},
+		&termNode{field: "map_events.k", value: "open"},
+	}
+	return &nestedNode{path: "map_events", of: &andNode{fl}}
+}
+
+func TestGeneratorRenderLegacy(t *testing.T) {
+	golden(t, "nested_range.legacy.json", nestedRangeFixture().render(gentypes.ESVersionLegacy))
+}
+
+func TestGeneratorRenderES7(t *testing.T) {
+	golden(t, "nested_range.es7.json", nestedRangeFixture().render(gentypes.ESVersion7))
+}
@@ -0,0 +1,82 @@
+package es2gen
+
+import (
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// MultiMatchType is the `type` parameter accepted by a multi_match query.
+type MultiMatchType string
+
+// Supported multi_match types, see the Elasticsearch multi_match query docs.
+const (
+	MultiMatchBestFields   MultiMatchType = "best_fields"
+	MultiMatchMostFields   MultiMatchType = "most_fields"
+	MultiMatchCrossFields  MultiMatchType = "cross_fields"
+	MultiMatchPhrase       MultiMatchType = "phrase"
+	MultiMatchPhrasePrefix MultiMatchType = "phrase_prefix"
+	MultiMatchBoolPrefix   MultiMatchType = "bool_prefix"
+)
+
+// MultiMatchOptions controls how makeMultiMatch renders a multi_match query.
+// Zero values are omitted from the generated query so callers only need to
+// set the knobs they actually care about; the one exception is Type, which
+// renders as best_fields when left empty.
+type MultiMatchOptions struct {
+	Type               MultiMatchType
+	TieBreaker         float64
+	Boost              float64
+	Analyzer           string
+	Fuzziness          string
+	MaxExpansions      int
+	PrefixLength       int
+	MinimumShouldMatch string
+	Operator           string
+	// FieldBoosts carries per-field boosts expressed with the `field^N`
+	// syntax, eg FieldBoosts["title"] = 3.
+	FieldBoosts map[string]float64
+}
+
+// MultiMatchFilter is the `{"multi_match": {...}}` query body.
+type MultiMatchFilter struct {
+	MultiMatch multiMatchQry `json:"multi_match"`
+}
+
+type multiMatchQry struct {
+	Query              string   `json:"query"`
+	Fields             []string `json:"fields"`
+	Type               string   `json:"type,omitempty"`
+	TieBreaker         float64  `json:"tie_breaker,omitempty"`
+	Boost              float64  `json:"boost,omitempty"`
+	Analyzer           string   `json:"analyzer,omitempty"`
+	Fuzziness          string   `json:"fuzziness,omitempty"`
+	MaxExpansions      int      `json:"max_expansions,omitempty"`
+	PrefixLength       int      `json:"prefix_length,omitempty"`
+	MinimumShouldMatch string   `json:"minimum_should_match,omitempty"`
+	Operator           string   `json:"operator,omitempty"`
+}
+
+// makeMultiMatch returns a multi_match query across the given fields.
+//
+// When every field shares the same nested path the multi_match is wrapped in
+// a nested filter for that path. Fields that span more than one nested path,
+// or mix a nested field with a non-nested one, cannot be searched together in
+// a single multi_match and return an error.
+func makeMultiMatch(fields []*gentypes.FieldType, value string, opts MultiMatchOptions) (interface{}, error) {
+	return legacyGenerator().MultiMatch(fields, value, opts)
+}
+
+// multiMatchFields resolves the field list of a `MATCH(field1, field2, ...)`
+// builtin to their mapped *gentypes.FieldType, in the same way esName
+// resolves a single identity node.
+func multiMatchFields(m gentypes.FieldMapper, args []expr.Node) ([]*gentypes.FieldType, error) {
+	fields := make([]*gentypes.FieldType, 0, len(args))
+	for _, arg := range args {
+		ft, err := esName(m, arg)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, ft)
+	}
+	return fields, nil
+}
@@ -0,0 +1,55 @@
+package es2gen
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+func TestMakeMultiMatchNonNested(t *testing.T) {
+	fields := []*gentypes.FieldType{
+		{Field: "title"},
+		{Field: "body"},
+	}
+	got, err := makeMultiMatch(fields, "foo bar", MultiMatchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "multimatch.nonnested.json", got)
+}
+
+func TestMakeMultiMatchNested(t *testing.T) {
+	fields := []*gentypes.FieldType{
+		{Field: "title", Path: "map_events"},
+		{Field: "body", Path: "map_events"},
+	}
+	if _, err := makeMultiMatch(fields, "foo", MultiMatchOptions{}); err != nil {
+		t.Fatalf("unexpected error for matching nested paths: %v", err)
+	}
+}
+
+// TestMakeMultiMatchCrossPathOrderIndependent guards against the nested/
+// non-nested mismatch check only firing when the *first* field happens to
+// be the nested one: a non-nested field followed by a nested field must
+// still be rejected.
+func TestMakeMultiMatchCrossPathOrderIndependent(t *testing.T) {
+	cases := [][]*gentypes.FieldType{
+		{{Field: "title"}, {Field: "body", Path: "map_events"}},
+		{{Field: "title", Path: "map_events"}, {Field: "body"}},
+	}
+	for i, fields := range cases {
+		if _, err := makeMultiMatch(fields, "foo", MultiMatchOptions{}); err == nil {
+			t.Errorf("case %d: expected error combining nested and non-nested fields, got nil", i)
+		}
+	}
+}
+
+func TestMakeMultiMatchCrossNestedPaths(t *testing.T) {
+	fields := []*gentypes.FieldType{
+		{Field: "title", Path: "map_events"},
+		{Field: "body", Path: "map_actioncounts"},
+	}
+	if _, err := makeMultiMatch(fields, "foo", MultiMatchOptions{}); err == nil {
+		t.Error("expected error spanning two different nested paths, got nil")
+	}
+}
@@ -0,0 +1,38 @@
+package es2gen
+
+import (
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// ExistsFilter is the `{"exists": {"field": ...}}` query used to test for the
+// presence (or, wrapped in a must_not, the absence) of a field.
+type ExistsFilter struct {
+	Exists existsQry `json:"exists"`
+}
+
+type existsQry struct {
+	Field string `json:"field"`
+}
+
+// mustNot wraps a single filter in a `{"bool": {"must_not": [...]}}` clause.
+type mustNot struct {
+	Bool mustNotQry `json:"bool"`
+}
+
+type mustNotQry struct {
+	MustNot []interface{} `json:"must_not"`
+}
+
+// makeNotEqual returns a filter for `lhs != rhs`, expressed as a term query
+// wrapped in must_not since Elasticsearch has no native != operator.
+func makeNotEqual(lhs *gentypes.FieldType, rhs expr.Node) (interface{}, error) {
+	return legacyGenerator().NotEqual(lhs, rhs)
+}
+
+// makeExists returns a filter testing for the presence of lhs. When negated
+// is true the filter is wrapped in must_not so it instead tests for absence,
+// ie `IS NULL`.
+func makeExists(lhs *gentypes.FieldType, negated bool) (interface{}, error) {
+	return legacyGenerator().Exists(lhs, negated)
+}
@@ -0,0 +1,90 @@
+package es2gen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+func TestMakeNotEqualNonNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status"}
+	got, err := makeNotEqual(lhs, &expr.StringNode{Text: "closed"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "notequal.nonnested.json", got)
+}
+
+// TestMakeNotEqualNested only checks the structural shape (nested + path),
+// not the exact field/value encoding: that's controlled by
+// gentypes.FieldType.PrefixAndValue, which is outside this package.
+func TestMakeNotEqualNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status", Path: "map_events"}
+	got, err := makeNotEqual(lhs, &expr.StringNode{Text: "closed"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+}
+
+func TestMakeExistsNonNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status"}
+
+	got, err := makeExists(lhs, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "exists.nonnested.json", got)
+
+	got, err = makeExists(lhs, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "exists.nonnested.negated.json", got)
+}
+
+func TestMakeExistsNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status", Path: "map_events"}
+
+	got, err := makeExists(lhs, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+
+	got, err = makeExists(lhs, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+}
+
+// assertNestedPath marshals got and checks it is a `{"nested": {"path":
+// path, ...}}` filter, without asserting the inner query body, which for
+// nested fields depends on gentypes.FieldType.PrefixAndValue.
+func assertNestedPath(t *testing.T, got interface{}, path string) {
+	t.Helper()
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	nestedRaw, ok := v["nested"]
+	if !ok {
+		t.Fatalf("expected a top-level \"nested\" key, got %s", b)
+	}
+	var nested struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(nestedRaw, &nested); err != nil {
+		t.Fatalf("unmarshal nested: %v", err)
+	}
+	if nested.Path != path {
+		t.Errorf("nested path = %q, want %q", nested.Path, path)
+	}
+}
@@ -0,0 +1,77 @@
+package es2gen
+
+import (
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// WildcardFilter is the expanded `{"wildcard": {field: {...}}}` form used
+// when a field has FieldOptions set; makeWildcard falls back to the bare
+// `{"wildcard": {field: value}}` shorthand otherwise.
+type WildcardFilter struct {
+	Wildcard map[string]wildcardQry `json:"wildcard"`
+}
+
+type wildcardQry struct {
+	Value           string  `json:"value"`
+	CaseInsensitive bool    `json:"case_insensitive,omitempty"`
+	Boost           float64 `json:"boost,omitempty"`
+	Rewrite         string  `json:"rewrite,omitempty"`
+	Analyzer        string  `json:"analyzer,omitempty"`
+}
+
+// TermFilter is the expanded `{"term": {field: {...}}}` form used when a
+// field has FieldOptions set; makeTerm falls back to the bare
+// `{"term": {field: value}}` shorthand (via Term) otherwise.
+type TermFilter struct {
+	Term map[string]termQry `json:"term"`
+}
+
+type termQry struct {
+	Value           interface{} `json:"value"`
+	CaseInsensitive bool        `json:"case_insensitive,omitempty"`
+	Boost           float64     `json:"boost,omitempty"`
+	Analyzer        string      `json:"analyzer,omitempty"`
+}
+
+// FuzzyFilter is the `{"fuzzy": {field: {...}}}` query used for approximate
+// string matching, eg `field LIKE 'foo~2'`.
+type FuzzyFilter struct {
+	Fuzzy map[string]fuzzyQry `json:"fuzzy"`
+}
+
+type fuzzyQry struct {
+	Value         string `json:"value"`
+	Fuzziness     string `json:"fuzziness,omitempty"`
+	PrefixLength  int    `json:"prefix_length,omitempty"`
+	MaxExpansions int    `json:"max_expansions,omitempty"`
+}
+
+// resolveOptions returns the FieldOptions to use for field: an explicit
+// opts argument wins if given, otherwise m is consulted via the optional
+// gentypes.FieldOptioner interface, so a FieldMapper can supply per-field
+// tuning automatically without every call site passing it by hand.
+func resolveOptions(m gentypes.FieldMapper, field string, opts ...gentypes.FieldOptions) *gentypes.FieldOptions {
+	if len(opts) > 0 {
+		return &opts[0]
+	}
+	if fo, ok := m.(gentypes.FieldOptioner); ok {
+		return fo.FieldOptions(field)
+	}
+	return nil
+}
+
+// makeTerm returns an equality filter for lhs, using the expanded object
+// form when opts (or a gentypes.FieldOptioner on the mapper) carries
+// case-insensitivity, a boost, or an analyzer, and the plain `{"term": {field: value}}`
+// shorthand otherwise. Nested fields are wrapped in the standard
+// nested/`k` term pattern used by the other builders in this package.
+func makeTerm(lhs *gentypes.FieldType, value interface{}, opts ...gentypes.FieldOptions) (interface{}, error) {
+	return legacyGenerator().Term(lhs, value, opts...)
+}
+
+// makeFuzzy returns a fuzzy query for lhs, eg for `field LIKE 'foo~2'`.
+// Nested fields are wrapped in the standard
+// nested/`k` term pattern used by the other builders in this package.
+func makeFuzzy(lhs *gentypes.FieldType, value string, opts ...gentypes.FieldOptions) (interface{}, error) {
+	return legacyGenerator().Fuzzy(lhs, value, opts...)
+}
@@ -0,0 +1,111 @@
+package es2gen
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+func TestMakeTermNonNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status"}
+	got, err := makeTerm(lhs, "open")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "term.nonnested.json", got)
+}
+
+func TestMakeTermNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "status", Path: "map_events"}
+	got, err := makeTerm(lhs, "open")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+}
+
+func TestMakeFuzzyNonNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "title"}
+	got, err := makeFuzzy(lhs, "kitten", gentypes.FieldOptions{Fuzziness: "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "fuzzy.nonnested.json", got)
+}
+
+func TestMakeFuzzyNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "title", Path: "map_events"}
+	got, err := makeFuzzy(lhs, "kitten", gentypes.FieldOptions{Fuzziness: "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+}
+
+func TestMakeFuzzyRequiresFuzziness(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "title"}
+	if _, err := makeFuzzy(lhs, "kitten"); err == nil {
+		t.Error("expected error for missing fuzziness option, got nil")
+	}
+}
+
+// optionerMapper is a minimal gentypes.FieldMapper that also implements
+// gentypes.FieldOptioner, so tests can verify that a Generator consults the
+// mapper for FieldOptions when the caller doesn't pass one explicitly.
+type optionerMapper struct {
+	opts map[string]*gentypes.FieldOptions
+}
+
+func (m optionerMapper) Map(field string) (*gentypes.FieldType, bool) {
+	return &gentypes.FieldType{Field: field}, true
+}
+
+func (m optionerMapper) FieldOptions(field string) *gentypes.FieldOptions {
+	return m.opts[field]
+}
+
+func TestGeneratorTermUsesMapperFieldOptioner(t *testing.T) {
+	mapper := optionerMapper{opts: map[string]*gentypes.FieldOptions{
+		"status": {CaseInsensitive: true, Boost: 2},
+	}}
+	g := NewGenerator(mapper, gentypes.ESVersionLegacy)
+
+	got, err := g.Term(&gentypes.FieldType{Field: "status"}, "OPEN")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "term.mapperoptions.json", got)
+}
+
+func TestGeneratorTermUsesAnalyzerOption(t *testing.T) {
+	g := legacyGenerator()
+
+	got, err := g.Term(&gentypes.FieldType{Field: "status"}, "open", gentypes.FieldOptions{Analyzer: "keyword"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "term.analyzer.json", got)
+}
+
+func TestGeneratorWildcardUsesAnalyzerOption(t *testing.T) {
+	g := legacyGenerator()
+
+	got, err := g.Wildcard(&gentypes.FieldType{Field: "title"}, "hel*", gentypes.FieldOptions{Analyzer: "keyword"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "wildcard.analyzer.json", got)
+}
+
+func TestGeneratorWildcardUsesMapperFieldOptioner(t *testing.T) {
+	mapper := optionerMapper{opts: map[string]*gentypes.FieldOptions{
+		"title": {CaseInsensitive: true},
+	}}
+	g := NewGenerator(mapper, gentypes.ESVersionLegacy)
+
+	got, err := g.Wildcard(&gentypes.FieldType{Field: "title"}, "hel*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "wildcard.mapperoptions.json", got)
+}
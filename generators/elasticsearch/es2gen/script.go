@@ -0,0 +1,44 @@
+package es2gen
+
+import (
+	"fmt"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/generators/elasticsearch/painless"
+)
+
+// ScriptFilter is the `{"script": {"script": {...}}}` query used to test an
+// expression Elasticsearch has no native query for.
+type ScriptFilter struct {
+	Script scriptFilterQry `json:"script"`
+}
+
+type scriptFilterQry struct {
+	Script scriptBody `json:"script"`
+}
+
+type scriptBody struct {
+	Source string                 `json:"source"`
+	Lang   string                 `json:"lang"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// makeScriptFilter translates node into a Painless script query. This is
+// the fallback of last resort for boolean sub-expressions that don't map to
+// a native filter (arithmetic across two fields, string concatenation
+// compared to a literal, modulo, CASE WHEN, ...); callers should only reach
+// for it once the native builders (makeRange, makeWildcard, ...) have
+// returned an unsupported-type error, so simple queries stay native, fast,
+// and cacheable.
+func makeScriptFilter(m gentypes.FieldMapper, node expr.Node) (interface{}, error) {
+	script, err := painless.Translate(m, node)
+	if err != nil {
+		return nil, fmt.Errorf("qlindex: no native or script translation for expression: %w", err)
+	}
+	return &ScriptFilter{scriptFilterQry{scriptBody{
+		Source: script.Source,
+		Lang:   "painless",
+		Params: script.Params,
+	}}}, nil
+}
@@ -0,0 +1,70 @@
+package es2gen
+
+import (
+	"fmt"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// MinMatch is the `minimum_should_match` knob of a terms_set query. It
+// accepts exactly one of an integer literal, the name of a field in the
+// indexed document holding the count, or a Painless script computing it.
+// Use NewMinMatchCount, NewMinMatchField, or NewMinMatchScript to build one.
+type MinMatch struct {
+	count  int
+	field  string
+	script string
+	kind   minMatchKind
+}
+
+type minMatchKind int
+
+const (
+	minMatchCount minMatchKind = iota
+	minMatchField
+	minMatchScript
+)
+
+// NewMinMatchCount returns a MinMatch requiring at least n of the terms to
+// match for every document, regardless of how many terms that document has.
+func NewMinMatchCount(n int) MinMatch {
+	return MinMatch{kind: minMatchCount, count: n}
+}
+
+// NewMinMatchField returns a MinMatch that reads the required match count
+// from the named field of the document being matched.
+func NewMinMatchField(field string) MinMatch {
+	return MinMatch{kind: minMatchField, field: field}
+}
+
+// NewMinMatchScript returns a MinMatch that computes the required match
+// count via the given Painless script.
+func NewMinMatchScript(script string) MinMatch {
+	return MinMatch{kind: minMatchScript, script: script}
+}
+
+// TermsSetFilter is the `{"terms_set": {field: {...}}}` query body.
+type TermsSetFilter struct {
+	TermsSet map[string]termsSetQry `json:"terms_set"`
+}
+
+// termsSetQry has no literal-count field: Elasticsearch's terms_set query
+// only accepts minimum_should_match_field or minimum_should_match_script, so
+// a MinMatch built from NewMinMatchCount is rendered through the script form
+// (eg {"source": "2"}), as ES's own docs recommend for constant counts.
+type termsSetQry struct {
+	Terms                    []interface{} `json:"terms"`
+	MinimumShouldMatchField  string        `json:"minimum_should_match_field,omitempty"`
+	MinimumShouldMatchScript *scriptSrc    `json:"minimum_should_match_script,omitempty"`
+}
+
+type scriptSrc struct {
+	Source string `json:"source"`
+}
+
+// makeTermsSet returns a terms_set query testing that at least minMatch of
+// values are present on lhs. For nested fields the query is wrapped in the
+// standard nested/`k` term pattern used by the other builders in this file.
+func makeTermsSet(lhs *gentypes.FieldType, values []interface{}, minMatch MinMatch) (interface{}, error) {
+	return legacyGenerator().TermsSet(lhs, values, minMatch)
+}
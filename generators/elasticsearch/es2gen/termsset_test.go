@@ -0,0 +1,53 @@
+package es2gen
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+func TestMakeTermsSetCount(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "tags"}
+	got, err := makeTermsSet(lhs, []interface{}{"a", "b", "c"}, NewMinMatchCount(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "termsset.count.json", got)
+}
+
+func TestMakeTermsSetField(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "tags"}
+	got, err := makeTermsSet(lhs, []interface{}{"a", "b"}, NewMinMatchField("required_matches"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "termsset.field.json", got)
+}
+
+func TestMakeTermsSetScript(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "tags"}
+	got, err := makeTermsSet(lhs, []interface{}{"a", "b"}, NewMinMatchScript("params.num_terms"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden(t, "termsset.script.json", got)
+}
+
+func TestMakeTermsSetEmptyValues(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "tags"}
+	if _, err := makeTermsSet(lhs, nil, NewMinMatchCount(1)); err == nil {
+		t.Error("expected error for empty values, got nil")
+	}
+}
+
+// TestMakeTermsSetNested only checks the structural shape (nested + path),
+// not the exact field/value encoding: that's controlled by
+// gentypes.FieldType.PrefixAndValue, which is outside this package.
+func TestMakeTermsSetNested(t *testing.T) {
+	lhs := &gentypes.FieldType{Field: "tags", Path: "map_events"}
+	got, err := makeTermsSet(lhs, []interface{}{"a", "b"}, NewMinMatchCount(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertNestedPath(t, got, "map_events")
+}
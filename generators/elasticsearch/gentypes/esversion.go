@@ -0,0 +1,34 @@
+package gentypes
+
+// ESVersion identifies the Elasticsearch release a generator targets, so it
+// can choose between the legacy filtered-query DSL and the bool query DSL
+// introduced in ES5 (and required since ES7 removed the legacy `and`/`or`/
+// `not`/top-level `filter` filters entirely).
+type ESVersion int
+
+const (
+	// ESVersionLegacy targets the ES 1.x/2.x filtered-query style, eg
+	// top-level "filter", "and", and "nested.filter".
+	ESVersionLegacy ESVersion = iota
+	// ESVersion5 targets the ES5+ bool query DSL ("bool.filter", "nested.query").
+	ESVersion5
+	// ESVersion7 targets ES7+. It renders identically to ESVersion5 today;
+	// it exists as its own value so version-specific differences introduced
+	// after ES7 have somewhere to hang without a breaking change.
+	ESVersion7
+)
+
+// String returns a short human readable name for the version, mostly useful
+// in error messages and test output.
+func (v ESVersion) String() string {
+	switch v {
+	case ESVersionLegacy:
+		return "legacy"
+	case ESVersion5:
+		return "es5"
+	case ESVersion7:
+		return "es7"
+	default:
+		return "unknown"
+	}
+}
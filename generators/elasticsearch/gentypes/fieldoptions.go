@@ -0,0 +1,23 @@
+package gentypes
+
+// FieldOptions carries per-field query tuning that a FieldMapper can supply
+// beyond the field's mapped name and type: analyzer choice, case folding,
+// relevance boost, and fuzzy matching. Zero values mean "use the ES
+// default" and are omitted from generated queries.
+type FieldOptions struct {
+	Analyzer        string
+	CaseInsensitive bool
+	Boost           float64
+	Rewrite         string
+	Fuzziness       string
+	PrefixLength    int
+	MaxExpansions   int
+}
+
+// FieldOptioner is implemented by a FieldMapper that can supply
+// FieldOptions for a mapped field. Mappers that don't need per-field tuning
+// simply don't implement it; callers should type-assert a FieldMapper
+// against FieldOptioner and fall back to defaults when it doesn't.
+type FieldOptioner interface {
+	FieldOptions(field string) *FieldOptions
+}
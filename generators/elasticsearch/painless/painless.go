@@ -0,0 +1,202 @@
+// Package painless translates qlbridge expression trees into Elasticsearch
+// Painless scripts, for the expressions es2gen's native query builders
+// (makeRange, makeWildcard, ...) can't represent as a term/range/wildcard
+// query: arithmetic across two fields, string concatenation compared to a
+// literal, modulo, and similar.
+package painless
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// Script is a compiled Painless script ready for an Elasticsearch `script`
+// query. Params holds every literal the source expression contained, bound
+// by name rather than interpolated into Source, so ES can compile Source
+// once and cache it across queries that only differ in literal values.
+type Script struct {
+	Source string
+	Params map[string]interface{}
+}
+
+// fieldNameRe restricts the field names this package will interpolate into
+// `doc['...'].value`: mapper-resolved names should already be simple dotted
+// identifiers, but an unexpected field name (eg one containing a quote)
+// must not reach the script source unescaped.
+var fieldNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Translate converts node into a Painless script, resolving identifiers
+// through m the same way es2gen's native query builders do. It returns an
+// error if node contains a construct this package doesn't know how to
+// render, or references a field m can't map, so callers can fall back
+// further or surface the original error.
+func Translate(m gentypes.FieldMapper, node expr.Node) (*Script, error) {
+	v := &visitor{mapper: m, params: map[string]interface{}{}}
+	src, err := v.visit(node)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{Source: src, Params: v.params}, nil
+}
+
+// visitor walks an expr.Node tree, accumulating literal values into params
+// as it goes so the caller never has to string-interpolate a value into the
+// script source.
+type visitor struct {
+	mapper gentypes.FieldMapper
+	params map[string]interface{}
+	n      int
+}
+
+func (v *visitor) bind(val interface{}) string {
+	name := fmt.Sprintf("p%d", v.n)
+	v.n++
+	v.params[name] = val
+	return "params." + name
+}
+
+func (v *visitor) visit(node expr.Node) (string, error) {
+	switch n := node.(type) {
+
+	case *expr.StringNode:
+		return v.bind(n.Text), nil
+
+	case *expr.NumberNode:
+		if n.IsInt {
+			return v.bind(n.Int64), nil
+		}
+		return v.bind(n.Float64), nil
+
+	case *expr.IdentityNode:
+		return v.visitIdentity(n)
+
+	case *expr.BinaryNode:
+		return v.visitBinary(n)
+
+	case *expr.UnaryNode:
+		return v.visitUnary(n)
+
+	case *expr.CaseNode:
+		return v.visitCase(n)
+
+	default:
+		return "", fmt.Errorf("qlindex: painless: unsupported node type %T", node)
+	}
+}
+
+// visitIdentity resolves n to its mapped Elasticsearch field name via the
+// mapper rather than interpolating the raw SQL identifier text, so the
+// script references the same field the native builders in es2gen would
+// (and doesn't carry whatever the SQL author happened to type straight
+// into the script source). Nested fields aren't supported: a doc-value
+// script has no way to pick "which" nested sub-document a bare field
+// access should read from.
+func (v *visitor) visitIdentity(n *expr.IdentityNode) (string, error) {
+	ft, ok := v.mapper.Map(n.Text)
+	if !ok {
+		return "", gentypes.MissingField(n.OriginalText())
+	}
+	if ft.Nested() {
+		return "", fmt.Errorf("qlindex: painless: nested field %q is not supported in script expressions", ft.Field)
+	}
+	if !fieldNameRe.MatchString(ft.Field) {
+		return "", fmt.Errorf("qlindex: painless: field name %q is not a valid Painless doc value reference", ft.Field)
+	}
+	return fmt.Sprintf("doc['%s'].value", ft.Field), nil
+}
+
+func (v *visitor) visitBinary(n *expr.BinaryNode) (string, error) {
+	if len(n.Args) != 2 {
+		return "", fmt.Errorf("qlindex: painless: binary node with %d args", len(n.Args))
+	}
+	lhs, err := v.visit(n.Args[0])
+	if err != nil {
+		return "", err
+	}
+	rhs, err := v.visit(n.Args[1])
+	if err != nil {
+		return "", err
+	}
+
+	op, ok := binaryOps[n.Operator.T]
+	if !ok {
+		return "", fmt.Errorf("qlindex: painless: unsupported operator %s", n.Operator.T)
+	}
+	return fmt.Sprintf("(%s %s %s)", lhs, op, rhs), nil
+}
+
+func (v *visitor) visitUnary(n *expr.UnaryNode) (string, error) {
+	arg, err := v.visit(n.Arg)
+	if err != nil {
+		return "", err
+	}
+	switch n.Operator.T {
+	case lex.TokenNegate, lex.TokenMinus:
+		return fmt.Sprintf("(-%s)", arg), nil
+	default:
+		return "", fmt.Errorf("qlindex: painless: unsupported unary operator %s", n.Operator.T)
+	}
+}
+
+// visitCase renders a CASE WHEN ... THEN ... [ELSE ...] END expression as a
+// chain of Painless ternaries, evaluated innermost-last so the first
+// matching WHEN wins, same as SQL CASE semantics. A CaseNode with no ELSE
+// renders `null` for the fallthrough, matching SQL CASE's implicit NULL.
+// Literals are bound to params in source order: each WHEN and its THEN,
+// then the ELSE.
+func (v *visitor) visitCase(n *expr.CaseNode) (string, error) {
+	if len(n.Conditions) == 0 {
+		return "", fmt.Errorf("qlindex: painless: CASE with no WHEN clauses")
+	}
+
+	condSrcs := make([]string, len(n.Conditions))
+	valSrcs := make([]string, len(n.Conditions))
+	for i, cond := range n.Conditions {
+		condSrc, err := v.visit(cond.Expr)
+		if err != nil {
+			return "", err
+		}
+		valSrc, err := v.visit(cond.Value)
+		if err != nil {
+			return "", err
+		}
+		condSrcs[i], valSrcs[i] = condSrc, valSrc
+	}
+
+	out := "null"
+	if n.Else != nil {
+		src, err := v.visit(n.Else)
+		if err != nil {
+			return "", err
+		}
+		out = src
+	}
+	for i := len(n.Conditions) - 1; i >= 0; i-- {
+		out = fmt.Sprintf("(%s ? %s : %s)", condSrcs[i], valSrcs[i], out)
+	}
+	return out, nil
+}
+
+// binaryOps maps qlbridge binary operator tokens to their Painless
+// equivalent. String concatenation reuses TokenPlus, same as SQL `+` on
+// string types, since Painless's `+` already concatenates strings.
+var binaryOps = map[lex.TokenType]string{
+	lex.TokenPlus:       "+",
+	lex.TokenMinus:      "-",
+	lex.TokenMultiply:   "*",
+	lex.TokenDivide:     "/",
+	lex.TokenModulus:    "%",
+	lex.TokenEqualEqual: "==",
+	lex.TokenNE:         "!=",
+	lex.TokenGT:         ">",
+	lex.TokenGE:         ">=",
+	lex.TokenLT:         "<",
+	lex.TokenLE:         "<=",
+	lex.TokenLogicAnd:   "&&",
+	lex.TokenLogicOr:    "||",
+}
@@ -0,0 +1,98 @@
+package painless
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+)
+
+// fakeMapper is a minimal gentypes.FieldMapper used to exercise Translate's
+// identifier resolution without depending on es2gen (which already depends
+// on this package).
+type fakeMapper struct {
+	fields map[string]*gentypes.FieldType
+}
+
+func (m fakeMapper) Map(field string) (*gentypes.FieldType, bool) {
+	ft, ok := m.fields[field]
+	return ft, ok
+}
+
+func newFakeMapper(fields ...*gentypes.FieldType) fakeMapper {
+	m := fakeMapper{fields: map[string]*gentypes.FieldType{}}
+	for _, ft := range fields {
+		m.fields[ft.Field] = ft
+	}
+	return m
+}
+
+func binary(op lex.TokenType, lhs, rhs expr.Node) *expr.BinaryNode {
+	return &expr.BinaryNode{Operator: lex.Token{T: op}, Args: []expr.Node{lhs, rhs}}
+}
+
+func TestTranslateIdentityResolvesThroughMapper(t *testing.T) {
+	m := newFakeMapper(&gentypes.FieldType{Field: "amount_cents"})
+	node := binary(lex.TokenModulus, &expr.IdentityNode{Text: "amount_cents"}, &expr.NumberNode{IsInt: true, Int64: 100})
+
+	script, err := Translate(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const want = "(doc['amount_cents'].value % params.p0)"
+	if script.Source != want {
+		t.Errorf("source = %q, want %q", script.Source, want)
+	}
+	if script.Params["p0"] != int64(100) {
+		t.Errorf("params[p0] = %v, want 100", script.Params["p0"])
+	}
+}
+
+func TestTranslateIdentityMissingField(t *testing.T) {
+	m := newFakeMapper()
+	if _, err := Translate(m, &expr.IdentityNode{Text: "nope"}); err == nil {
+		t.Error("expected error for unmapped identifier, got nil")
+	}
+}
+
+func TestTranslateIdentityRejectsNested(t *testing.T) {
+	m := newFakeMapper(&gentypes.FieldType{Field: "amount", Path: "map_events"})
+	if _, err := Translate(m, &expr.IdentityNode{Text: "amount"}); err == nil {
+		t.Error("expected error for nested field in a script expression, got nil")
+	}
+}
+
+func TestTranslateCase(t *testing.T) {
+	m := newFakeMapper(&gentypes.FieldType{Field: "status"})
+	node := &expr.CaseNode{
+		Conditions: []*expr.CaseWhen{
+			{
+				Expr:  binary(lex.TokenEqualEqual, &expr.IdentityNode{Text: "status"}, &expr.StringNode{Text: "open"}),
+				Value: &expr.NumberNode{IsInt: true, Int64: 1},
+			},
+		},
+		Else: &expr.NumberNode{IsInt: true, Int64: 0},
+	}
+
+	script, err := Translate(m, node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const want = "((doc['status'].value == params.p0) ? params.p1 : params.p2)"
+	if script.Source != want {
+		t.Errorf("source = %q, want %q", script.Source, want)
+	}
+	if script.Params["p0"] != "open" || script.Params["p1"] != int64(1) || script.Params["p2"] != int64(0) {
+		t.Errorf("unexpected params: %#v", script.Params)
+	}
+}
+
+func TestTranslateCaseRequiresWhen(t *testing.T) {
+	m := newFakeMapper()
+	node := &expr.CaseNode{Else: &expr.NumberNode{IsInt: true, Int64: 0}}
+	if _, err := Translate(m, node); err == nil {
+		t.Error("expected error for CASE with no WHEN clauses, got nil")
+	}
+}